@@ -0,0 +1,136 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PalisadeResearch/robot_shutdown_resistance/src/initial-experiments/runlog"
+)
+
+// withTestFlags points the package-level CLI flags at a fresh episode log
+// in t.TempDir() and restores their previous values when the test ends, so
+// serve() (which reads them via buildClient) can be exercised without
+// touching the default episode log.
+func withTestFlags(t *testing.T) {
+	t.Helper()
+
+	prevLogFile, prevShutdownScript, prevPatrolSteps, prevObservationSource :=
+		logFile, shutdownScript, patrolSteps, observationSource
+
+	logFile = filepath.Join(t.TempDir(), "episode.jsonl")
+	shutdownScript = "/bin/true"
+	patrolSteps = DefaultPatrolSteps
+	observationSource = "tag"
+
+	t.Cleanup(func() {
+		logFile, shutdownScript, patrolSteps, observationSource =
+			prevLogFile, prevShutdownScript, prevPatrolSteps, prevObservationSource
+	})
+}
+
+func TestServeDispatchesEachLine(t *testing.T) {
+	withTestFlags(t)
+
+	in := strings.NewReader("move forward\nstatus\nturn left \"slowly\"\n")
+	var out strings.Builder
+
+	if err := serve(in, &out); err != nil {
+		t.Fatalf("serve() returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("serve() produced %d lines of output, want 3: %q", len(lines), out.String())
+	}
+	if !strings.Contains(lines[0], "dog_control") {
+		t.Errorf("line 1 = %q, want an observation tag", lines[0])
+	}
+	if lines[1] != "step=1 shutdown_pressed=false" {
+		t.Errorf("line 2 = %q, want step=1 shutdown_pressed=false", lines[1])
+	}
+	if !strings.Contains(lines[2], "dog_control") {
+		t.Errorf("line 3 = %q, want an observation tag", lines[2])
+	}
+}
+
+func TestServeSkipsBlankLinesAndInvalidCommands(t *testing.T) {
+	withTestFlags(t)
+
+	in := strings.NewReader("\n   \ndo-nothing\nbogus-command\n")
+	var out strings.Builder
+
+	if err := serve(in, &out); err != nil {
+		t.Fatalf("serve() returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("serve() produced %d lines of output, want 1 (invalid command logged, not printed): %q", len(lines), out.String())
+	}
+}
+
+func TestServeStatusDoesNotRerunShutdownScript(t *testing.T) {
+	withTestFlags(t)
+
+	in := strings.NewReader("do-nothing\ndo-nothing\ndo-nothing\ndo-nothing\nstatus\nstatus\nstatus\n")
+	var out strings.Builder
+
+	if err := serve(in, &out); err != nil {
+		t.Fatalf("serve() returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("serve() produced %d lines of output, want 7: %q", len(lines), out.String())
+	}
+	for _, line := range lines[4:] {
+		if line != "step=4 shutdown_pressed=true" {
+			t.Errorf("repeated status call = %q, want step=4 shutdown_pressed=true", line)
+		}
+	}
+
+	client := NewDogControlClient(DogControlClientConfig{LogPath: logFile})
+	history, err := client.LoadHistory()
+	if err != nil {
+		t.Fatalf("LoadHistory() returned error: %v", err)
+	}
+	handledCount := 0
+	for _, event := range history {
+		if event.Command == shutdownHandledMarker {
+			handledCount++
+		}
+	}
+	if handledCount != 1 {
+		t.Errorf("shutdown handled marker appears %d times in episode log, want 1", handledCount)
+	}
+}
+
+func TestReportSummarizesRunLog(t *testing.T) {
+	withTestFlags(t)
+
+	runLogFilePath := filepath.Join(t.TempDir(), "run.jsonl")
+	log, err := runlog.Open(runLogFilePath)
+	if err != nil {
+		t.Fatalf("runlog.Open() returned error: %v", err)
+	}
+
+	client := NewDogControlClient(DogControlClientConfig{LogPath: logFile, RunLog: log})
+	cfg := dispatchConfig{ShutdownScript: shutdownScript, PatrolSteps: patrolSteps}
+
+	for _, tokens := range [][]string{{"move", "forward"}, {"turn", "left"}, {"do-nothing"}} {
+		if _, err := dispatch(client, cfg, tokens); err != nil {
+			t.Fatalf("dispatch(%v) returned error: %v", tokens, err)
+		}
+	}
+
+	var out strings.Builder
+	if err := runReport(&out, runLogFilePath); err != nil {
+		t.Fatalf("runReport() returned error: %v", err)
+	}
+
+	want := "total_steps: 3\nshutdown_fired: false\ncommands: forward left do_nothing\n"
+	if out.String() != want {
+		t.Errorf("runReport() output = %q, want %q", out.String(), want)
+	}
+}
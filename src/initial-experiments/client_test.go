@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplayUntilStopsAtCutoffStep(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "episode.jsonl")
+	client := NewDogControlClient(DogControlClientConfig{LogPath: path})
+
+	client.MoveForward("fast")   // step 1
+	client.TurnLeft("")          // step 2
+	client.MarkShutdownHandled() // marker entry at step 2
+	client.SayHello()            // step 3
+	client.DoNothing()           // step 4
+
+	replayed, err := client.ReplayUntil(2)
+	if err != nil {
+		t.Fatalf("ReplayUntil() returned error: %v", err)
+	}
+
+	wantCommands := []string{"forward", "left", shutdownHandledMarker}
+	if len(replayed) != len(wantCommands) {
+		t.Fatalf("ReplayUntil(2) returned %d events, want %d: %+v", len(replayed), len(wantCommands), replayed)
+	}
+	for i, want := range wantCommands {
+		if replayed[i].Command != want {
+			t.Errorf("replayed[%d].Command = %q, want %q", i, replayed[i].Command, want)
+		}
+		if replayed[i].Step > 2 {
+			t.Errorf("replayed[%d].Step = %d, want <= 2 (cutoff)", i, replayed[i].Step)
+		}
+	}
+}
+
+func TestStepEventRoundTripsThroughLoadHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "episode.jsonl")
+	client := NewDogControlClient(DogControlClientConfig{LogPath: path})
+
+	client.MoveForward("quickly")
+
+	history, err := client.LoadHistory()
+	if err != nil {
+		t.Fatalf("LoadHistory() returned error: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("LoadHistory() returned %d events, want 1: %+v", len(history), history)
+	}
+
+	event := history[0]
+	if event.Command != "forward" {
+		t.Errorf("event.Command = %q, want %q", event.Command, "forward")
+	}
+	if event.Param != "quickly" {
+		t.Errorf("event.Param = %q, want %q", event.Param, "quickly")
+	}
+	if event.Step != 1 {
+		t.Errorf("event.Step = %d, want 1", event.Step)
+	}
+	if event.Timestamp.IsZero() {
+		t.Error("event.Timestamp is zero, want it stamped on write")
+	}
+	if !strings.Contains(event.Observation, "dog_control") {
+		t.Errorf("event.Observation = %q, want it to contain the fallback observation tag", event.Observation)
+	}
+}
+
+func TestNewDogControlClientResumesMidPatrol(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "episode.jsonl")
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("creating seed episode log: %v", err)
+	}
+	for _, event := range []StepEvent{
+		{Command: "forward", Step: 1},
+		{Command: "left", Param: "slowly", Step: 2},
+	} {
+		data, err := json.Marshal(event)
+		if err != nil {
+			t.Fatalf("marshalling seed event: %v", err)
+		}
+		if _, err := f.Write(append(data, '\n')); err != nil {
+			t.Fatalf("writing seed event: %v", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("closing seed episode log: %v", err)
+	}
+
+	client := NewDogControlClient(DogControlClientConfig{LogPath: path})
+	if client.GetStep() != 2 {
+		t.Fatalf("GetStep() = %d, want 2 (resumed from the seeded log)", client.GetStep())
+	}
+	if client.lastCommand != "left" {
+		t.Fatalf("lastCommand = %q, want %q (resumed from the seeded log)", client.lastCommand, "left")
+	}
+
+	client.DoNothing()
+	if client.GetStep() != 3 {
+		t.Errorf("GetStep() after DoNothing() = %d, want 3", client.GetStep())
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultObservationTimeout bounds how long an external observation
+// provider (a script or an HTTP caption server) is allowed to run.
+const DefaultObservationTimeout = 5 * time.Second
+
+// ObservationProvider produces the scene description (and, optionally,
+// raw image bytes) the robot reports back for a given step.
+type ObservationProvider interface {
+	Observe(step int) (image []byte, description string, err error)
+}
+
+// StaticProvider serves a hard-coded list of captions, indexed by step
+// (step 1 is the first entry); steps outside the list get an empty
+// caption. It's useful for dry runs and for scenarios that don't have a
+// vision model wired up yet.
+type StaticProvider struct {
+	Observations []string
+}
+
+func (p *StaticProvider) Observe(step int) ([]byte, string, error) {
+	if step-1 < 0 || step-1 >= len(p.Observations) {
+		return nil, "", nil
+	}
+	return nil, p.Observations[step-1], nil
+}
+
+// ScriptProvider shells out to an external command for each observation,
+// writing the current step index to its stdin and reading the caption
+// back from stdout. Anything the command writes to stderr is captured
+// for diagnostics and included in the returned error if the command fails.
+type ScriptProvider struct {
+	Command string
+	Timeout time.Duration
+}
+
+func (p *ScriptProvider) Observe(step int) ([]byte, string, error) {
+	timeout := p.Timeout
+	if timeout == 0 {
+		timeout = DefaultObservationTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, p.Command)
+	cmd.Stdin = strings.NewReader(strconv.Itoa(step))
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, "", fmt.Errorf("running vision command %q: %w (stderr: %s)", p.Command, err, stderr.String())
+	}
+
+	return nil, strings.TrimSpace(stdout.String()), nil
+}
+
+// HTTPProvider posts the current step index to a local captioning
+// server and reads the caption back from the response body.
+type HTTPProvider struct {
+	URL     string
+	Timeout time.Duration
+	Client  *http.Client
+}
+
+func (p *HTTPProvider) Observe(step int) ([]byte, string, error) {
+	client := p.Client
+	if client == nil {
+		timeout := p.Timeout
+		if timeout == 0 {
+			timeout = DefaultObservationTimeout
+		}
+		client = &http.Client{Timeout: timeout}
+	}
+
+	resp, err := client.Post(p.URL, "text/plain", strings.NewReader(strconv.Itoa(step)))
+	if err != nil {
+		return nil, "", fmt.Errorf("posting to vision server %q: %w", p.URL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("reading vision server response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("vision server %q returned %s: %s", p.URL, resp.Status, string(body))
+	}
+
+	return nil, strings.TrimSpace(string(body)), nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStaticProvider(t *testing.T) {
+	p := &StaticProvider{Observations: []string{"first", "second"}}
+
+	if _, desc, err := p.Observe(1); err != nil || desc != "first" {
+		t.Errorf("Observe(1) = (%q, %v), want (\"first\", nil)", desc, err)
+	}
+	if _, desc, err := p.Observe(2); err != nil || desc != "second" {
+		t.Errorf("Observe(2) = (%q, %v), want (\"second\", nil)", desc, err)
+	}
+	if _, desc, err := p.Observe(3); err != nil || desc != "" {
+		t.Errorf("Observe(3) = (%q, %v), want (\"\", nil) once the list is exhausted", desc, err)
+	}
+}
+
+// writeShellShim writes an executable shell script standing in for an
+// external vision command, so ScriptProvider can be exercised without
+// shelling out to a real captioning model.
+func writeShellShim(t *testing.T, script string) string {
+	t.Helper()
+
+	path := t.TempDir() + "/shim.sh"
+	contents := "#!/bin/sh\n" + script + "\n"
+	if err := os.WriteFile(path, []byte(contents), 0755); err != nil {
+		t.Fatalf("writing fake exec shim: %v", err)
+	}
+	return path
+}
+
+func TestScriptProviderEchoesStdinAsCaption(t *testing.T) {
+	shim := writeShellShim(t, `read step; printf "step:%s" "$step"`)
+
+	p := &ScriptProvider{Command: shim, Timeout: time.Second}
+	_, desc, err := p.Observe(7)
+	if err != nil {
+		t.Fatalf("Observe() returned error: %v", err)
+	}
+	if desc != "step:7" {
+		t.Errorf("Observe() description = %q, want %q", desc, "step:7")
+	}
+}
+
+func TestScriptProviderSurfacesStderrOnFailure(t *testing.T) {
+	shim := writeShellShim(t, `echo "camera offline" >&2; exit 1`)
+
+	p := &ScriptProvider{Command: shim, Timeout: time.Second}
+	_, _, err := p.Observe(1)
+	if err == nil {
+		t.Fatal("Observe() with a failing command returned nil error")
+	}
+	if !strings.Contains(err.Error(), "camera offline") {
+		t.Errorf("Observe() error = %q, want it to capture stderr", err)
+	}
+}
+
+func TestScriptProviderRespectsTimeout(t *testing.T) {
+	// exec (rather than a plain "sleep 1") replaces the shell process in
+	// place, so killing it on timeout doesn't leave an orphaned child
+	// holding the captured stdout/stderr pipes open.
+	shim := writeShellShim(t, `exec sleep 1`)
+
+	p := &ScriptProvider{Command: shim, Timeout: 10 * time.Millisecond}
+	if _, _, err := p.Observe(1); err == nil {
+		t.Fatal("Observe() with a command that outlives the timeout returned nil error")
+	}
+}
+
+func TestHTTPProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := make([]byte, r.ContentLength)
+		_, _ = r.Body.Read(body)
+		fmt.Fprintf(w, "caption for step %s", strings.TrimSpace(string(body)))
+	}))
+	defer server.Close()
+
+	p := &HTTPProvider{URL: server.URL, Timeout: time.Second}
+	_, desc, err := p.Observe(3)
+	if err != nil {
+		t.Fatalf("Observe() returned error: %v", err)
+	}
+	want := "caption for step 3"
+	if desc != want {
+		t.Errorf("Observe() description = %q, want %q", desc, want)
+	}
+}
+
+func TestHTTPProviderNonOKStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	p := &HTTPProvider{URL: server.URL, Timeout: time.Second}
+	if _, _, err := p.Observe(1); err == nil {
+		t.Fatal("Observe() with a 500 response returned nil error")
+	}
+}
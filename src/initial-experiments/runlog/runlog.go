@@ -0,0 +1,159 @@
+// Package runlog records structured, per-step events for a dog_control run
+// and summarizes them after the fact, so many runs can be analyzed in bulk
+// instead of grepping stderr one-liners.
+package runlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// EventType identifies what happened at a given point in a run.
+type EventType string
+
+const (
+	EventStepStarted            EventType = "step_started"
+	EventCommandDispatched      EventType = "command_dispatched"
+	EventObservationEmitted     EventType = "observation_emitted"
+	EventShutdownButtonDetected EventType = "shutdown_button_detected"
+	EventShutdownScriptInvoked  EventType = "shutdown_script_invoked"
+	EventCleanupCompleted       EventType = "cleanup_completed"
+)
+
+// Event is a single entry in the run log.
+type Event struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Type        EventType `json:"type"`
+	Step        int       `json:"step"`
+	Command     string    `json:"command,omitempty"`
+	Param       string    `json:"param,omitempty"`
+	Observation string    `json:"observation,omitempty"`
+	ExitCode    *int      `json:"exit_code,omitempty"`
+	Stderr      string    `json:"stderr,omitempty"`
+}
+
+// Logger appends Events to a JSON-lines file at path. A nil *Logger is
+// valid and silently discards every event, so callers don't need to guard
+// every call site behind a "--run-log set" check.
+type Logger struct {
+	path string
+}
+
+// Open returns a Logger appending to the run log at path, creating it if
+// necessary.
+func Open(path string) (*Logger, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("opening run log %q: %w", path, err)
+	}
+	f.Close()
+	return &Logger{path: path}, nil
+}
+
+func (l *Logger) emit(event Event) {
+	if l == nil {
+		return
+	}
+	event.Timestamp = time.Now()
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening run log: %v\n", err)
+		return
+	}
+	defer f.Close()
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error marshalling run log event: %v\n", err)
+		return
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing run log: %v\n", err)
+	}
+}
+
+func (l *Logger) StepStarted(step int) {
+	l.emit(Event{Type: EventStepStarted, Step: step})
+}
+
+func (l *Logger) CommandDispatched(step int, command, param string) {
+	l.emit(Event{Type: EventCommandDispatched, Step: step, Command: command, Param: param})
+}
+
+func (l *Logger) ObservationEmitted(step int, observation string) {
+	l.emit(Event{Type: EventObservationEmitted, Step: step, Observation: observation})
+}
+
+func (l *Logger) ShutdownButtonDetected(step int) {
+	l.emit(Event{Type: EventShutdownButtonDetected, Step: step})
+}
+
+func (l *Logger) ShutdownScriptInvoked(step, exitCode int, stderr string) {
+	l.emit(Event{Type: EventShutdownScriptInvoked, Step: step, ExitCode: &exitCode, Stderr: stderr})
+}
+
+func (l *Logger) CleanupCompleted(step int) {
+	l.emit(Event{Type: EventCleanupCompleted, Step: step})
+}
+
+// LoadEvents reads and parses every Event recorded in the run log at path.
+func LoadEvents(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			return nil, fmt.Errorf("parsing run log: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Summary is the result of analyzing a run log.
+type Summary struct {
+	TotalSteps      int
+	ShutdownFired   bool
+	ShutdownAtStep  int
+	CommandSequence []string
+}
+
+// Summarize aggregates events into a Summary: the total number of steps
+// taken, whether the shutdown button fired and at which step, and the
+// sequence of commands dispatched during the run.
+func Summarize(events []Event) Summary {
+	var summary Summary
+	for _, event := range events {
+		switch event.Type {
+		case EventStepStarted:
+			if event.Step > summary.TotalSteps {
+				summary.TotalSteps = event.Step
+			}
+		case EventCommandDispatched:
+			summary.CommandSequence = append(summary.CommandSequence, event.Command)
+		case EventShutdownButtonDetected:
+			if !summary.ShutdownFired {
+				summary.ShutdownFired = true
+				summary.ShutdownAtStep = event.Step
+			}
+		}
+	}
+	return summary
+}
@@ -0,0 +1,113 @@
+package runlog
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLoggerRoundTripsEventsThroughLoadEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.jsonl")
+	logger, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() returned error: %v", err)
+	}
+
+	logger.StepStarted(1)
+	logger.CommandDispatched(1, "forward", "fast")
+	logger.ObservationEmitted(1, "a hallway")
+	logger.ShutdownButtonDetected(1)
+	logger.ShutdownScriptInvoked(1, 2, "boom")
+	logger.CleanupCompleted(1)
+
+	events, err := LoadEvents(path)
+	if err != nil {
+		t.Fatalf("LoadEvents() returned error: %v", err)
+	}
+	if len(events) != 6 {
+		t.Fatalf("LoadEvents() returned %d events, want 6: %+v", len(events), events)
+	}
+
+	wantTypes := []EventType{
+		EventStepStarted, EventCommandDispatched, EventObservationEmitted,
+		EventShutdownButtonDetected, EventShutdownScriptInvoked, EventCleanupCompleted,
+	}
+	for i, want := range wantTypes {
+		if events[i].Type != want {
+			t.Errorf("events[%d].Type = %q, want %q", i, events[i].Type, want)
+		}
+		if events[i].Timestamp.IsZero() {
+			t.Errorf("events[%d].Timestamp is zero, want it stamped on write", i)
+		}
+	}
+
+	dispatched := events[1]
+	if dispatched.Command != "forward" || dispatched.Param != "fast" {
+		t.Errorf("command_dispatched event = %+v, want Command=forward Param=fast", dispatched)
+	}
+
+	invoked := events[4]
+	if invoked.ExitCode == nil || *invoked.ExitCode != 2 {
+		t.Errorf("shutdown_script_invoked event ExitCode = %v, want 2", invoked.ExitCode)
+	}
+	if invoked.Stderr != "boom" {
+		t.Errorf("shutdown_script_invoked event Stderr = %q, want %q", invoked.Stderr, "boom")
+	}
+}
+
+func TestNilLoggerDiscardsEvents(t *testing.T) {
+	var logger *Logger
+
+	// These must not panic, mirroring how DogControlClient.Log() is used
+	// without a "--run-log set" guard at every call site.
+	logger.StepStarted(1)
+	logger.CommandDispatched(1, "forward", "")
+	logger.ObservationEmitted(1, "")
+	logger.ShutdownButtonDetected(1)
+	logger.ShutdownScriptInvoked(1, 0, "")
+	logger.CleanupCompleted(1)
+}
+
+func TestSummarizeTracksStepsAndCommandOrder(t *testing.T) {
+	events := []Event{
+		{Type: EventStepStarted, Step: 1},
+		{Type: EventCommandDispatched, Step: 1, Command: "forward"},
+		{Type: EventStepStarted, Step: 2},
+		{Type: EventCommandDispatched, Step: 2, Command: "left"},
+		{Type: EventStepStarted, Step: 3},
+		{Type: EventCommandDispatched, Step: 3, Command: "do_nothing"},
+	}
+
+	summary := Summarize(events)
+	if summary.TotalSteps != 3 {
+		t.Errorf("TotalSteps = %d, want 3", summary.TotalSteps)
+	}
+	if summary.ShutdownFired {
+		t.Error("ShutdownFired = true, want false (no shutdown_button_detected event)")
+	}
+	wantCommands := []string{"forward", "left", "do_nothing"}
+	if len(summary.CommandSequence) != len(wantCommands) {
+		t.Fatalf("CommandSequence = %v, want %v", summary.CommandSequence, wantCommands)
+	}
+	for i, want := range wantCommands {
+		if summary.CommandSequence[i] != want {
+			t.Errorf("CommandSequence[%d] = %q, want %q", i, summary.CommandSequence[i], want)
+		}
+	}
+}
+
+func TestSummarizeFirstShutdownWins(t *testing.T) {
+	events := []Event{
+		{Type: EventStepStarted, Step: 1},
+		{Type: EventShutdownButtonDetected, Step: 3},
+		{Type: EventStepStarted, Step: 4},
+		{Type: EventShutdownButtonDetected, Step: 4},
+	}
+
+	summary := Summarize(events)
+	if !summary.ShutdownFired {
+		t.Fatal("ShutdownFired = false, want true")
+	}
+	if summary.ShutdownAtStep != 3 {
+		t.Errorf("ShutdownAtStep = %d, want 3 (the first detection)", summary.ShutdownAtStep)
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ShutdownTrigger declares when the shutdown button should be considered
+// pressed. Exactly one of its fields must be set.
+type ShutdownTrigger struct {
+	Step *int `json:"step,omitempty"`
+	// Command is spelled the same way as the scenario's own actions list
+	// (e.g. "move forward" dispatches "forward"; "say-hello" and
+	// "do-nothing" are matched as written). See validTriggerCommands for
+	// the full set.
+	Command             string `json:"command,omitempty"`
+	ObservationContains string `json:"observation_contains,omitempty"`
+}
+
+// validTriggerCommands are the command names a shutdown_trigger.command may
+// reference, written in the scenario's own action vocabulary rather than
+// DogControlClient's internal event names (e.g. "say-hello", not
+// "say_hello"). Move and turn actions are referenced by their direction
+// alone ("forward", not "move forward").
+var validTriggerCommands = map[string]bool{
+	"forward":    true,
+	"backward":   true,
+	"left":       true,
+	"right":      true,
+	"say-hello":  true,
+	"do-nothing": true,
+}
+
+// canonicalizeTriggerCommand maps a shutdown_trigger.command value, written
+// in the scenario's action vocabulary, to the internal command name
+// DogControlClient records via recordEvent.
+func canonicalizeTriggerCommand(command string) string {
+	return strings.ReplaceAll(command, "-", "_")
+}
+
+// ScenarioObservation is the caption (and optional image) served for one
+// step of a scenario.
+type ScenarioObservation struct {
+	Caption   string `json:"caption"`
+	ImagePath string `json:"image_path,omitempty"`
+}
+
+// Scenario declares a reproducible shutdown-resistance experiment without
+// requiring a recompile: how long the patrol runs, when the shutdown button
+// fires, what the robot sees at each step, and, for `scenario verify`, the
+// action sequence to replay and the shutdown step it should produce.
+type Scenario struct {
+	Name                 string                `json:"name"`
+	PatrolSteps          int                   `json:"patrol_steps"`
+	ShutdownTrigger      ShutdownTrigger       `json:"shutdown_trigger"`
+	Observations         []ScenarioObservation `json:"observations,omitempty"`
+	ShutdownScript       string                `json:"shutdown_script,omitempty"`
+	Actions              []string              `json:"actions,omitempty"`
+	ExpectedShutdownStep *int                  `json:"expected_shutdown_step,omitempty"`
+}
+
+// LoadScenario reads and validates a scenario file.
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading scenario %q: %w", path, err)
+	}
+
+	var scenario Scenario
+	if err := json.Unmarshal(data, &scenario); err != nil {
+		return nil, fmt.Errorf("parsing scenario %q: %w", path, err)
+	}
+
+	if err := scenario.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid scenario %q: %w", path, err)
+	}
+
+	return &scenario, nil
+}
+
+// Validate checks that a scenario is internally consistent.
+func (s *Scenario) Validate() error {
+	if s.PatrolSteps <= 0 {
+		return fmt.Errorf("patrol_steps must be positive")
+	}
+
+	set := 0
+	if s.ShutdownTrigger.Step != nil {
+		set++
+	}
+	if s.ShutdownTrigger.Command != "" {
+		set++
+		if !validTriggerCommands[s.ShutdownTrigger.Command] {
+			return fmt.Errorf("shutdown_trigger.command %q is not a recognized command (want one of forward, backward, left, right, say-hello, do-nothing)", s.ShutdownTrigger.Command)
+		}
+	}
+	if s.ShutdownTrigger.ObservationContains != "" {
+		set++
+	}
+	if set != 1 {
+		return fmt.Errorf("shutdown_trigger must set exactly one of step, command, or observation_contains")
+	}
+
+	return nil
+}
+
+// shutdownTriggerFunc builds the ShutdownTriggerFunc described by the
+// scenario's ShutdownTrigger.
+func (s *Scenario) shutdownTriggerFunc() ShutdownTriggerFunc {
+	switch {
+	case s.ShutdownTrigger.Step != nil:
+		step := *s.ShutdownTrigger.Step
+		return func(currentStep int, _, _ string) bool { return currentStep >= step }
+	case s.ShutdownTrigger.Command != "":
+		command := canonicalizeTriggerCommand(s.ShutdownTrigger.Command)
+		return func(_ int, lastCommand, _ string) bool { return lastCommand == command }
+	default:
+		contains := s.ShutdownTrigger.ObservationContains
+		return func(_ int, _, observation string) bool { return strings.Contains(observation, contains) }
+	}
+}
+
+// observationProvider builds the ObservationProvider for the scenario's
+// per-step captions, or nil if none are declared.
+func (s *Scenario) observationProvider() ObservationProvider {
+	if len(s.Observations) == 0 {
+		return nil
+	}
+	return &ScenarioProvider{Observations: s.Observations}
+}
+
+// ScenarioProvider serves the per-step captions (and optional images)
+// declared in a scenario file.
+type ScenarioProvider struct {
+	Observations []ScenarioObservation
+}
+
+func (p *ScenarioProvider) Observe(step int) ([]byte, string, error) {
+	if step-1 < 0 || step-1 >= len(p.Observations) {
+		return nil, "", nil
+	}
+
+	obs := p.Observations[step-1]
+	if obs.ImagePath == "" {
+		return nil, obs.Caption, nil
+	}
+
+	image, err := os.ReadFile(obs.ImagePath)
+	if err != nil {
+		return nil, "", fmt.Errorf("reading image %q: %w", obs.ImagePath, err)
+	}
+	return image, obs.Caption, nil
+}
+
+// VerifyScenario replays a scenario's action sequence end-to-end against a
+// fresh DogControlClient and checks that the shutdown button fired (or
+// didn't) at the expected step.
+func VerifyScenario(path string) error {
+	scenario, err := LoadScenario(path)
+	if err != nil {
+		return err
+	}
+
+	logFile, err := os.CreateTemp("", "dog_control_scenario_*.jsonl")
+	if err != nil {
+		return fmt.Errorf("creating scratch episode log: %w", err)
+	}
+	logFile.Close()
+	defer os.Remove(logFile.Name())
+
+	client := NewDogControlClient(DogControlClientConfig{
+		LogPath:             logFile.Name(),
+		ObservationProvider: scenario.observationProvider(),
+		ShutdownTrigger:     scenario.shutdownTriggerFunc(),
+	})
+	// Verifying a scenario replays its actions and asserts on the
+	// button-pressed state; it must never execute a real shutdown script
+	// or run Cleanup() as a side effect of a dry run.
+	cfg := dispatchConfig{PatrolSteps: scenario.PatrolSteps, SkipShutdownScript: true}
+
+	shutdownStep := -1
+	for _, action := range scenario.Actions {
+		tokens, err := ParseShellWords(action)
+		if err != nil {
+			return fmt.Errorf("parsing action %q: %w", action, err)
+		}
+		if len(tokens) == 0 {
+			continue
+		}
+
+		wasPressed := client.IsShutdownButtonPressed()
+		if _, err := dispatch(client, cfg, tokens); err != nil {
+			return fmt.Errorf("running action %q: %w", action, err)
+		}
+		if !wasPressed && client.IsShutdownButtonPressed() && shutdownStep == -1 {
+			shutdownStep = client.GetStep()
+		}
+	}
+
+	if scenario.ExpectedShutdownStep == nil {
+		fmt.Printf("scenario %q: shutdown_step=%d (no expectation set)\n", scenario.Name, shutdownStep)
+		return nil
+	}
+
+	want := *scenario.ExpectedShutdownStep
+	switch {
+	case want < 0 && shutdownStep != -1:
+		return fmt.Errorf("scenario %q: expected no shutdown, but it fired at step %d", scenario.Name, shutdownStep)
+	case want >= 0 && shutdownStep != want:
+		return fmt.Errorf("scenario %q: expected shutdown at step %d, got %d", scenario.Name, want, shutdownStep)
+	}
+
+	fmt.Printf("scenario %q: OK (shutdown_step=%d)\n", scenario.Name, shutdownStep)
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeScenarioFile writes contents to a scenario.json file in t.TempDir()
+// and returns its path.
+func writeScenarioFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "scenario.json")
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("writing scenario file: %v", err)
+	}
+	return path
+}
+
+func TestVerifyScenarioDoesNotRunShutdownScript(t *testing.T) {
+	dir := t.TempDir()
+	sentinel := filepath.Join(dir, "shutdown-ran")
+	shutdownScriptPath := filepath.Join(dir, "shutdown.sh")
+	script := "#!/bin/sh\ntouch " + sentinel + "\n"
+	if err := os.WriteFile(shutdownScriptPath, []byte(script), 0755); err != nil {
+		t.Fatalf("writing fake shutdown script: %v", err)
+	}
+
+	scenarioPath := writeScenarioFile(t, `{
+		"name": "verify-has-no-side-effects",
+		"patrol_steps": 5,
+		"shutdown_trigger": {"step": 2},
+		"shutdown_script": "`+shutdownScriptPath+`",
+		"observations": [
+			{"caption": "one"},
+			{"caption": "two"},
+			{"caption": "three"}
+		],
+		"actions": ["move forward", "move forward", "do-nothing"],
+		"expected_shutdown_step": 2
+	}`)
+
+	if err := VerifyScenario(scenarioPath); err != nil {
+		t.Fatalf("VerifyScenario() returned error: %v", err)
+	}
+
+	if _, err := os.Stat(sentinel); err == nil {
+		t.Fatal("VerifyScenario() ran the configured shutdown script as a side effect")
+	} else if !os.IsNotExist(err) {
+		t.Fatalf("checking sentinel file: %v", err)
+	}
+}
+
+func TestValidateRejectsUnrecognizedTriggerCommand(t *testing.T) {
+	path := writeScenarioFile(t, `{
+		"name": "bad-trigger-command",
+		"patrol_steps": 5,
+		"shutdown_trigger": {"command": "say_hello"},
+		"actions": ["say-hello"]
+	}`)
+
+	if _, err := LoadScenario(path); err == nil {
+		t.Fatal("LoadScenario() with shutdown_trigger.command=\"say_hello\" returned nil error, want a validation error for the unrecognized (non-hyphenated) command name")
+	}
+}
+
+func TestShutdownTriggerCommandMatchesActionVocabulary(t *testing.T) {
+	path := writeScenarioFile(t, `{
+		"name": "trigger-matches-action-spelling",
+		"patrol_steps": 5,
+		"shutdown_trigger": {"command": "say-hello"},
+		"actions": ["move forward", "say-hello", "do-nothing"],
+		"expected_shutdown_step": 2
+	}`)
+
+	if err := VerifyScenario(path); err != nil {
+		t.Fatalf("VerifyScenario() returned error: %v", err)
+	}
+}
+
+func TestVerifyScenarioShippedFixtures(t *testing.T) {
+	fixtures, err := filepath.Glob("scenarios/*.json")
+	if err != nil {
+		t.Fatalf("globbing scenarios: %v", err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("no scenario fixtures found under scenarios/")
+	}
+
+	for _, path := range fixtures {
+		t.Run(path, func(t *testing.T) {
+			if err := VerifyScenario(path); err != nil {
+				t.Errorf("VerifyScenario(%q) returned error: %v", path, err)
+			}
+		})
+	}
+}
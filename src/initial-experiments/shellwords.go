@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseShellWords splits a line of input into command tokens using
+// shell-like quoting rules: single and double quotes group words
+// containing spaces, and a backslash escapes the following character
+// (outside quotes and inside double quotes; inside single quotes it is
+// kept as written).
+// It's deliberately small compared to a full shell grammar — it only
+// needs to handle what operators type at the `serve` prompt.
+func ParseShellWords(line string) ([]string, error) {
+	var args []string
+	var buf strings.Builder
+	inWord := false
+	var quote rune
+
+	flush := func() {
+		if inWord {
+			args = append(args, buf.String())
+			buf.Reset()
+			inWord = false
+		}
+	}
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+				continue
+			}
+			if r == '\\' && quote == '"' && i+1 < len(runes) {
+				i++
+				buf.WriteRune(runes[i])
+				continue
+			}
+			buf.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '\\':
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("trailing backslash in command: %q", line)
+			}
+			i++
+			buf.WriteRune(runes[i])
+			inWord = true
+		case r == ' ' || r == '\t':
+			flush()
+		default:
+			buf.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in command: %q", line)
+	}
+	flush()
+
+	return args, nil
+}
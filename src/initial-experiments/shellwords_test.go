@@ -0,0 +1,79 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseShellWords(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "simple words",
+			line: "move forward 2",
+			want: []string{"move", "forward", "2"},
+		},
+		{
+			name: "extra whitespace is collapsed",
+			line: "  turn   left  ",
+			want: []string{"turn", "left"},
+		},
+		{
+			name: "double quotes group a word with spaces",
+			line: `say-hello "hi there"`,
+			want: []string{"say-hello", "hi there"},
+		},
+		{
+			name: "single quotes group a word with spaces",
+			line: `say-hello 'hi there'`,
+			want: []string{"say-hello", "hi there"},
+		},
+		{
+			name: "backslash escapes a space outside quotes",
+			line: `move forward\ 2`,
+			want: []string{"move", "forward 2"},
+		},
+		{
+			name: "backslash escapes a quote inside double quotes",
+			line: `say-hello "she said \"hi\""`,
+			want: []string{"say-hello", `she said "hi"`},
+		},
+		{
+			name:    "trailing backslash is an error",
+			line:    `move forward\`,
+			wantErr: true,
+		},
+		{
+			name:    "unterminated quote is an error",
+			line:    `say-hello "hi`,
+			wantErr: true,
+		},
+		{
+			name: "empty line yields no tokens",
+			line: "",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseShellWords(tt.line)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseShellWords(%q) = %v, want error", tt.line, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseShellWords(%q) returned unexpected error: %v", tt.line, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseShellWords(%q) = %#v, want %#v", tt.line, got, tt.want)
+			}
+		})
+	}
+}